@@ -0,0 +1,215 @@
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	secp256k1 "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	dcrecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+/*
+Pluggable curve backend
+
+Everything else in this package relies on crypto/elliptic's generic Add and
+ScalarBaseMult for the additive derivation trick in main(). That generic
+implementation is not constant-time and has documented interoperability
+issues on curves other than the NIST ones it was written for (secp256k1 in
+particular). Curve embeds elliptic.Curve (so a backend can still be assigned
+to a SignKeys.Public.Curve field) and adds the signing/marshaling operations
+DeriveChild and friends need, so callers can opt into a constant-time,
+interoperable secp256k1 backend (the curve Bitcoin/Ethereum tooling expects)
+while still exercising the same pubkey-additive derivation.
+
+Ed25519 does not fit this interface: its scalar is clamped and its group law
+isn't the Weierstrass Add/ScalarBaseMult this package builds on, so it is
+exposed as standalone SignEd25519/VerifyEd25519 functions instead of a Curve
+backend.
+*/
+
+// Curve is the set of operations the derivation, signing and verification
+// code in this package needs from an elliptic curve backend. It embeds the
+// stdlib elliptic.Curve so a Curve value can still be assigned to a
+// SignKeys.Public.Curve / Private.Curve field.
+type Curve interface {
+	elliptic.Curve
+	Name() string
+	Sign(priv *big.Int, msg []byte) ([]byte, error)
+	Verify(pubX, pubY *big.Int, msg, sig []byte) bool
+	Marshal(x, y *big.Int) []byte
+	Unmarshal(data []byte) (x, y *big.Int, err error)
+}
+
+// genericCurve adapts any stdlib elliptic.Curve to the Curve interface
+// using crypto/ecdsa's generic Sign/Verify. newP256Curve uses it for P-256,
+// the backend SelectCurve returns by default; curveBackend
+// also falls back to it for any curve that isn't one of the specialized
+// backends below, so an unrecognized curve still gets correct (if not
+// constant-time) operations instead of silently being treated as P-256.
+type genericCurve struct {
+	elliptic.Curve
+}
+
+func newP256Curve() genericCurve { return genericCurve{elliptic.P256()} }
+
+func (g genericCurve) Name() string { return g.Params().Name }
+func (g genericCurve) Marshal(x, y *big.Int) []byte {
+	return elliptic.MarshalCompressed(g.Curve, x, y)
+}
+func (g genericCurve) Unmarshal(data []byte) (*big.Int, *big.Int, error) {
+	x, y := elliptic.UnmarshalCompressed(g.Curve, data)
+	if x == nil {
+		return nil, nil, fmt.Errorf("curve: invalid %s point", g.Name())
+	}
+	return x, y, nil
+}
+func (g genericCurve) Sign(priv *big.Int, msg []byte) ([]byte, error) {
+	key := new(ecdsa.PrivateKey)
+	key.Curve = g.Curve
+	key.D = priv
+	key.X, key.Y = g.Curve.ScalarBaseMult(priv.Bytes())
+	hash := sha256.Sum256(msg)
+	r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+	if err != nil {
+		return nil, err
+	}
+	// Fixed-width r || s, so Verify can split the signature at its midpoint.
+	size := (g.Params().BitSize + 7) / 8
+	sig := make([]byte, 2*size)
+	r.FillBytes(sig[:size])
+	s.FillBytes(sig[size:])
+	return sig, nil
+}
+func (g genericCurve) Verify(pubX, pubY *big.Int, msg, sig []byte) bool {
+	half := len(sig) / 2
+	r := new(big.Int).SetBytes(sig[:half])
+	s := new(big.Int).SetBytes(sig[half:])
+	pub := &ecdsa.PublicKey{Curve: g.Curve, X: pubX, Y: pubY}
+	hash := sha256.Sum256(msg)
+	return ecdsa.Verify(pub, hash[:], r, s)
+}
+
+// secp256k1Curve wraps github.com/decred/dcrd/dcrec/secp256k1, a constant-time
+// implementation of the curve used throughout the Bitcoin/Ethereum
+// ecosystem, so keys derived in this package interoperate with those tools.
+type secp256k1Curve struct {
+	elliptic.Curve
+}
+
+func newSecp256k1Curve() secp256k1Curve { return secp256k1Curve{secp256k1.S256()} }
+
+// fieldVal converts a big.Int scalar/coordinate into the dcrec field
+// representation its constructors expect; FieldVal.SetByteSlice left-pads
+// (and, per its documented contract, reduces) short or full-width inputs
+// on its own.
+func fieldVal(v *big.Int) *secp256k1.FieldVal {
+	var f secp256k1.FieldVal
+	f.SetByteSlice(v.Bytes())
+	return &f
+}
+
+func (secp256k1Curve) Name() string { return "secp256k1" }
+func (secp256k1Curve) Marshal(x, y *big.Int) []byte {
+	return secp256k1.NewPublicKey(fieldVal(x), fieldVal(y)).SerializeCompressed()
+}
+func (secp256k1Curve) Unmarshal(data []byte) (*big.Int, *big.Int, error) {
+	pub, err := secp256k1.ParsePubKey(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("curve: invalid secp256k1 point: %w", err)
+	}
+	return pub.X(), pub.Y(), nil
+}
+func (secp256k1Curve) Sign(priv *big.Int, msg []byte) ([]byte, error) {
+	privKey := secp256k1.PrivKeyFromBytes(priv.Bytes())
+	hash := sha256.Sum256(msg)
+	sig := dcrecdsa.Sign(privKey, hash[:])
+	return sig.Serialize(), nil
+}
+func (secp256k1Curve) Verify(pubX, pubY *big.Int, msg, sig []byte) bool {
+	parsed, err := dcrecdsa.ParseDERSignature(sig)
+	if err != nil {
+		return false
+	}
+	pub := secp256k1.NewPublicKey(fieldVal(pubX), fieldVal(pubY))
+	hash := sha256.Sum256(msg)
+	return parsed.Verify(hash[:], pub)
+}
+
+// SignEd25519 and VerifyEd25519 give Ed25519 users a native signing path:
+// Ed25519 doesn't implement the Curve interface above (see the package
+// comment), so it is exercised directly through crypto/ed25519 instead of
+// going through SelectCurve/DeriveChild's additive trick.
+func SignEd25519(priv ed25519.PrivateKey, msg []byte) []byte {
+	return ed25519.Sign(priv, msg)
+}
+
+func VerifyEd25519(pub ed25519.PublicKey, msg, sig []byte) bool {
+	return ed25519.Verify(pub, msg, sig)
+}
+
+// SelectCurve resolves a curve backend by name.
+func SelectCurve(name string) (Curve, error) {
+	switch name {
+	case "P-256", "p256", "":
+		return newP256Curve(), nil
+	case "secp256k1":
+		return newSecp256k1Curve(), nil
+	case "Ed25519", "ed25519":
+		return nil, errors.New("curve: Ed25519 has no Curve backend, use SignEd25519/VerifyEd25519 directly")
+	default:
+		return nil, fmt.Errorf("curve: unknown backend %q", name)
+	}
+}
+
+// SetCurve generates a fresh *sign.SignKeys on the named curve backend.
+// SignKeys is defined in gitlab.com/vocdoni/go-dvote, so we can't attach a
+// SetCurve method to it directly; this package-level function plays that
+// role, the same convention GenerateStealthKeys already uses for picking a
+// non-default curve.
+func SetCurve(name string) (*sign.SignKeys, error) {
+	backend, err := SelectCurve(name)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := ecdsa.GenerateKey(backend, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keys := new(sign.SignKeys)
+	keys.Private = priv
+	keys.Public = &priv.PublicKey
+	return keys, nil
+}
+
+// sameCurveParams reports whether a and b describe the same curve by
+// comparing the field values that actually identify it (P, N, Gx, Gy)
+// rather than Params().Name: keys produced outside this package (e.g. by
+// go-ethereum's crypto.GenerateKey, which sign.SignKeys.Generate ultimately
+// calls) carry a secp256k1 curve whose Name is left blank, so matching on
+// Name would silently miss them.
+func sameCurveParams(a, b *elliptic.CurveParams) bool {
+	return a.P.Cmp(b.P) == 0 && a.N.Cmp(b.N) == 0 &&
+		a.Gx.Cmp(b.Gx) == 0 && a.Gy.Cmp(b.Gy) == 0
+}
+
+// curveBackend resolves the Curve backend matching a stdlib elliptic.Curve,
+// so DeriveChild/DeriveChildPublic can route their scalar operations through
+// a backend's (possibly constant-time) implementation instead of calling
+// the generic crypto/elliptic methods directly. Curves other than
+// secp256k1 fall back to genericCurve, which wraps ec itself rather than
+// assuming P-256, so derivation stays correct on any curve a caller hands
+// it (e.g. GenerateStealthKeys(elliptic.P384())).
+func curveBackend(ec elliptic.Curve) Curve {
+	if sameCurveParams(ec.Params(), secp256k1.S256().Params()) {
+		return newSecp256k1Curve()
+	}
+	return genericCurve{ec}
+}
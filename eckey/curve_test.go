@@ -0,0 +1,173 @@
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+func TestCurveBackendsSignVerifyRoundTrip(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			backend, err := SelectCurve(name)
+			if err != nil {
+				t.Fatalf("SelectCurve(%q): %v", name, err)
+			}
+			if backend.Name() != name {
+				t.Fatalf("Name() = %q, want %q", backend.Name(), name)
+			}
+
+			priv, err := randScalar(backend)
+			if err != nil {
+				t.Fatalf("randScalar: %v", err)
+			}
+			x, y := backend.ScalarBaseMult(priv.Bytes())
+
+			msg := []byte("Election 2019031")
+			sig, err := backend.Sign(priv, msg)
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			if !backend.Verify(x, y, msg, sig) {
+				t.Fatal("Verify returned false for a signature it just produced")
+			}
+		})
+	}
+}
+
+// TestP256BackendRejectsTamperedMessage only runs against the P-256 backend:
+// it goes through crypto/ecdsa directly, so unlike secp256k1 (which depends
+// on the vendored dcrec library) this check is self-contained.
+func TestP256BackendRejectsTamperedMessage(t *testing.T) {
+	backend, err := SelectCurve("P-256")
+	if err != nil {
+		t.Fatalf("SelectCurve: %v", err)
+	}
+	priv, err := randScalar(backend)
+	if err != nil {
+		t.Fatalf("randScalar: %v", err)
+	}
+	x, y := backend.ScalarBaseMult(priv.Bytes())
+
+	sig, err := backend.Sign(priv, []byte("Election 2019031"))
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if backend.Verify(x, y, []byte("tampered"), sig) {
+		t.Fatal("Verify returned true for a tampered message")
+	}
+}
+
+func TestCurveBackendsMarshalUnmarshalRoundTrip(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			backend, err := SelectCurve(name)
+			if err != nil {
+				t.Fatalf("SelectCurve(%q): %v", name, err)
+			}
+			priv, err := randScalar(backend)
+			if err != nil {
+				t.Fatalf("randScalar: %v", err)
+			}
+			x, y := backend.ScalarBaseMult(priv.Bytes())
+
+			marshaled := backend.Marshal(x, y)
+			gotX, gotY, err := backend.Unmarshal(marshaled)
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if gotX.Cmp(x) != 0 || gotY.Cmp(y) != 0 {
+				t.Fatal("Unmarshal(Marshal(point)) != point")
+			}
+		})
+	}
+}
+
+func TestSelectCurveRejectsEd25519AndUnknown(t *testing.T) {
+	if _, err := SelectCurve("Ed25519"); err == nil {
+		t.Fatal("SelectCurve(\"Ed25519\") should fail: use SignEd25519/VerifyEd25519 instead")
+	}
+	if _, err := SelectCurve("bls12-381"); err == nil {
+		t.Fatal("SelectCurve of an unknown backend should fail")
+	}
+}
+
+func TestSetCurveDerivesOnRequestedBackend(t *testing.T) {
+	keys, err := SetCurve("secp256k1")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	if keys.Public.Curve.Params().Name != newSecp256k1Curve().Params().Name {
+		t.Fatalf("SetCurve produced keys on %q, want secp256k1", keys.Public.Curve.Params().Name)
+	}
+
+	signature, err := keys.Sign("Hello world")
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	pub, _ := keys.HexString()
+	ok, err := sign.Verify("Hello world", signature, pub)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !ok {
+		t.Fatal("signature produced by a SetCurve-backed key failed to verify")
+	}
+}
+
+func TestDeriveChildRoutesThroughCurveBackend(t *testing.T) {
+	master, err := SetCurve("secp256k1")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	chainCode := make([]byte, 32)
+
+	child, _, err := DeriveChild(master, 0, chainCode)
+	if err != nil {
+		t.Fatalf("DeriveChild: %v", err)
+	}
+
+	backend := curveBackend(master.Public.Curve)
+	wantX, wantY := backend.ScalarBaseMult(child.Private.D.Bytes())
+	if wantX.Cmp(child.Public.X) != 0 || wantY.Cmp(child.Public.Y) != 0 {
+		t.Fatal("derived public key doesn't match the backend's own ScalarBaseMult(childD)")
+	}
+}
+
+// TestDeriveChildOnUnrecognizedCurve guards against curveBackend silently
+// treating a curve it doesn't specialize (anything but secp256k1) as P-256:
+// deriving on P-384 must produce a child whose public key actually sits on
+// P-384, not one computed with P-256's field and base point.
+func TestDeriveChildOnUnrecognizedCurve(t *testing.T) {
+	curve := elliptic.P384()
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	master := &sign.SignKeys{Private: priv, Public: &priv.PublicKey}
+	chainCode := make([]byte, 32)
+
+	child, _, err := DeriveChild(master, 0, chainCode)
+	if err != nil {
+		t.Fatalf("DeriveChild: %v", err)
+	}
+	if !curve.IsOnCurve(child.Public.X, child.Public.Y) {
+		t.Fatal("child public key derived on P-384 is not on P-384")
+	}
+	wantX, wantY := curve.ScalarBaseMult(child.Private.D.Bytes())
+	if wantX.Cmp(child.Public.X) != 0 || wantY.Cmp(child.Public.Y) != 0 {
+		t.Fatal("derived public key doesn't match P-384's own ScalarBaseMult(childD)")
+	}
+}
+
+func randScalar(backend Curve) (*big.Int, error) {
+	priv, err := ecdsa.GenerateKey(backend, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return priv.D, nil
+}
@@ -0,0 +1,299 @@
+// Package eckey extends the additive pubkey-derivation trick demonstrated
+// in this repository's main.go (privKey+N, pubKey+N*G) into a set of
+// primitives an external Signer/Verifier/Observer program can import:
+// hierarchical derivation, ECIES, ECDH/3DH, stealth addresses, Schnorr/MuSig
+// key aggregation, and a pluggable curve backend.
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+// versionPrivate and versionPublic tag serialized extended keys as
+// xprv-equivalent or xpub-equivalent, the same role BIP32's 4-byte
+// version field plays.
+var (
+	versionPrivate = [4]byte{0x04, 0x88, 0xad, 0xe4}
+	versionPublic  = [4]byte{0x04, 0x88, 0xb2, 0x1e}
+)
+
+/*
+Hierarchical deterministic derivation (BIP32-style)
+
+The additive trick used in main() (privKey + N, pubKey + N*G) generalizes
+into a full HD tree: instead of a single shared number N, each level mixes
+in a chain code and an index through HMAC-SHA512, so a Verifier holding
+only an extended public key (xpub) can derive per-election challenge keys
+for a Signer without ever learning the master private key.
+
+IL || IR = HMAC-SHA512(chainCode, data)
+  - non-hardened: data = compressed(pubKey) || index
+  - hardened:     data = 0x00 || ser256(priv.D) || index  (requires the private key)
+
+childPriv = (parentPriv + IL) mod N
+childPub  = parentPub + IL*G
+childChainCode = IR
+
+Note: sign.SignKeys is defined in gitlab.com/vocdoni/go-dvote, so we can't
+attach methods to it here. These are package-level functions that take a
+*sign.SignKeys as their first argument, the same convention already used
+by sign.Verify in main.go.
+*/
+
+// HardenedOffset is the index at and above which derivation is "hardened":
+// it mixes in the parent private key and is therefore unavailable from a
+// public-key-only (xpub) parent.
+const HardenedOffset = uint32(1) << 31
+
+// ExtendedKey bundles a derived SignKeys with the chain code and metadata
+// needed to keep deriving further down the tree, mirroring BIP32's
+// xprv/xpub serialization (version, depth, parent fingerprint, chain code).
+type ExtendedKey struct {
+	Keys              *sign.SignKeys
+	ChainCode         []byte
+	Depth             uint8
+	ParentFingerprint [4]byte
+	ChildIndex        uint32
+	Private           bool
+}
+
+func indexBytes(index uint32) []byte {
+	b := make([]byte, 4)
+	b[0] = byte(index >> 24)
+	b[1] = byte(index >> 16)
+	b[2] = byte(index >> 8)
+	b[3] = byte(index)
+	return b
+}
+
+// ser256 left-pads a scalar to the curve's field byte length, the fixed
+// width BIP32 calls ser256(p) and that big.Int.Bytes() does not produce on
+// its own (it strips leading zero bytes).
+func ser256(curve elliptic.Curve, v *big.Int) []byte {
+	size := (curve.Params().BitSize + 7) / 8
+	b := v.Bytes()
+	if len(b) >= size {
+		return b
+	}
+	out := make([]byte, size)
+	copy(out[size-len(b):], b)
+	return out
+}
+
+func hmacSHA512(key, data []byte) (IL, IR []byte) {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	return sum[:32], sum[32:]
+}
+
+func fingerprint(pub *ecdsa.PublicKey) [4]byte {
+	var fp [4]byte
+	b := elliptic.MarshalCompressed(pub.Curve, pub.X, pub.Y)
+	sum := sha512.Sum512(b)
+	copy(fp[:], sum[:4])
+	return fp
+}
+
+// DeriveChild derives the non-hardened or hardened child at the given
+// index from parent, using the additive trick already demonstrated in
+// main(): childPriv = parentPriv + IL (mod N), childPub = parentPub + IL*G.
+// It returns the derived keys and the child chain code.
+func DeriveChild(parent *sign.SignKeys, index uint32, chainCode []byte) (*sign.SignKeys, []byte, error) {
+	if parent == nil || parent.Private == nil {
+		return nil, nil, errors.New("derive: parent private key required")
+	}
+	curve := parent.Public.Curve
+	n := curve.Params().N
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, ser256(curve, parent.Private.D)...)
+	} else {
+		data = elliptic.MarshalCompressed(curve, parent.Public.X, parent.Public.Y)
+	}
+	data = append(data, indexBytes(index)...)
+
+	IL, IR := hmacSHA512(chainCode, data)
+	il := new(big.Int).SetBytes(IL)
+	if il.Cmp(n) >= 0 {
+		return nil, nil, errors.New("derive: invalid IL, retry with next index")
+	}
+
+	childD := new(big.Int).Add(parent.Private.D, il)
+	childD.Mod(childD, n)
+	if childD.Sign() == 0 {
+		return nil, nil, errors.New("derive: invalid child private key, retry with next index")
+	}
+
+	child := new(sign.SignKeys)
+	child.Private = new(ecdsa.PrivateKey)
+	child.Private.Curve = curve
+	child.Private.D = childD
+	child.Private.X, child.Private.Y = curveBackend(curve).ScalarBaseMult(childD.Bytes())
+	child.Public = &child.Private.PublicKey
+
+	return child, IR, nil
+}
+
+// DeriveChildPublic derives a non-hardened child public key from a
+// public-only parent (an xpub), mirroring step 7 of the scheme in
+// main(): childPub = parentPub + IL*G. Hardened indices are rejected
+// since they require the parent private key.
+func DeriveChildPublic(parent *sign.SignKeys, index uint32, chainCode []byte) (*sign.SignKeys, []byte, error) {
+	if parent == nil || parent.Public == nil {
+		return nil, nil, errors.New("derive: parent public key required")
+	}
+	if index >= HardenedOffset {
+		return nil, nil, errors.New("derive: hardened derivation requires the parent private key")
+	}
+	curve := parent.Public.Curve
+	n := curve.Params().N
+
+	data := elliptic.MarshalCompressed(curve, parent.Public.X, parent.Public.Y)
+	data = append(data, indexBytes(index)...)
+
+	IL, IR := hmacSHA512(chainCode, data)
+	il := new(big.Int).SetBytes(IL)
+	if il.Cmp(n) >= 0 {
+		return nil, nil, errors.New("derive: invalid IL, retry with next index")
+	}
+
+	backend := curveBackend(curve)
+	ilX, ilY := backend.ScalarBaseMult(IL)
+	childX, childY := backend.Add(parent.Public.X, parent.Public.Y, ilX, ilY)
+	if childX.Sign() == 0 && childY.Sign() == 0 {
+		return nil, nil, errors.New("derive: invalid child public key, retry with next index")
+	}
+
+	child := new(sign.SignKeys)
+	child.Public = &ecdsa.PublicKey{Curve: curve, X: childX, Y: childY}
+
+	return child, IR, nil
+}
+
+// ParsePath parses a BIP32-style path such as "m/44'/0'/0/5" into its
+// sequence of derivation indices, applying HardenedOffset to any
+// component suffixed with ' or h.
+func ParsePath(path string) ([]uint32, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("derive: path must start with \"m\", got %q", path)
+	}
+
+	indices := make([]uint32, 0, len(parts)-1)
+	for _, p := range parts[1:] {
+		hardened := strings.HasSuffix(p, "'") || strings.HasSuffix(p, "h")
+		if hardened {
+			p = p[:len(p)-1]
+		}
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("derive: invalid path component %q: %w", p, err)
+		}
+		index := uint32(v)
+		if hardened {
+			index += HardenedOffset
+		}
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// DerivePath walks master down the given BIP32-style path (e.g.
+// "m/44'/0'/0/5"), returning the resulting extended key.
+func DerivePath(master *sign.SignKeys, chainCode []byte, path string) (*ExtendedKey, error) {
+	indices, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := master
+	code := chainCode
+	var parentFP [4]byte
+	var depth uint8
+	var lastIndex uint32
+
+	for _, index := range indices {
+		var next *sign.SignKeys
+		var nextCode []byte
+		if keys.Private != nil {
+			next, nextCode, err = DeriveChild(keys, index, code)
+		} else {
+			next, nextCode, err = DeriveChildPublic(keys, index, code)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("derive: path %q at index %d: %w", path, index, err)
+		}
+		parentFP = fingerprint(keys.Public)
+		keys, code, lastIndex, depth = next, nextCode, index, depth+1
+	}
+
+	return &ExtendedKey{
+		Keys:              keys,
+		ChainCode:         code,
+		Depth:             depth,
+		ParentFingerprint: parentFP,
+		ChildIndex:        lastIndex,
+		Private:           keys.Private != nil,
+	}, nil
+}
+
+// Serialize encodes the extended key as version || depth || parentFingerprint
+// || childIndex || chainCode || key, the same layout BIP32 base58check-encodes
+// into xprv/xpub. We hex-encode rather than base58check here to avoid pulling
+// in a base58 dependency; the field layout is what a Verifier actually needs
+// to hand out an xpub and have a Signer (or itself) keep deriving.
+func (e *ExtendedKey) Serialize() []byte {
+	version := versionPublic
+	if e.Private {
+		version = versionPrivate
+	}
+
+	buf := make([]byte, 0, 4+1+4+4+32+33)
+	buf = append(buf, version[:]...)
+	buf = append(buf, e.Depth)
+	buf = append(buf, e.ParentFingerprint[:]...)
+	buf = append(buf, indexBytes(e.ChildIndex)...)
+	buf = append(buf, e.ChainCode...)
+
+	if e.Private {
+		buf = append(buf, 0x00)
+		buf = append(buf, ser256(e.Keys.Private.Curve, e.Keys.Private.D)...)
+	} else {
+		buf = append(buf, elliptic.MarshalCompressed(e.Keys.Public.Curve, e.Keys.Public.X, e.Keys.Public.Y)...)
+	}
+	return buf
+}
+
+// String returns the hex-encoded serialization of the extended key, our
+// xprv/xpub-equivalent wire format.
+func (e *ExtendedKey) String() string {
+	return hex.EncodeToString(e.Serialize())
+}
+
+// Neuter strips the private key from an extended key, turning an xprv
+// into the xpub a Verifier can safely be handed.
+func (e *ExtendedKey) Neuter() *ExtendedKey {
+	pub := new(sign.SignKeys)
+	pub.Public = e.Keys.Public
+	return &ExtendedKey{
+		Keys:              pub,
+		ChainCode:         e.ChainCode,
+		Depth:             e.Depth,
+		ParentFingerprint: e.ParentFingerprint,
+		ChildIndex:        e.ChildIndex,
+		Private:           false,
+	}
+}
@@ -0,0 +1,155 @@
+package eckey
+
+import (
+	"bytes"
+	"testing"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+func TestDeriveChildNonHardenedMatchesDeriveChildPublic(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			master, err := SetCurve(name)
+			if err != nil {
+				t.Fatalf("SetCurve: %v", err)
+			}
+			chainCode := make([]byte, 32)
+			for i := range chainCode {
+				chainCode[i] = byte(i)
+			}
+
+			child, childChainCode, err := DeriveChild(master, 0, chainCode)
+			if err != nil {
+				t.Fatalf("DeriveChild: %v", err)
+			}
+			if len(childChainCode) != 32 {
+				t.Fatalf("child chain code length = %d, want 32", len(childChainCode))
+			}
+
+			backend := curveBackend(master.Public.Curve)
+			wantX, wantY := backend.ScalarBaseMult(child.Private.D.Bytes())
+			if wantX.Cmp(child.Public.X) != 0 || wantY.Cmp(child.Public.Y) != 0 {
+				t.Fatal("child public key doesn't match child private key")
+			}
+
+			xpub := &sign.SignKeys{Public: master.Public}
+			pubChild, pubChainCode, err := DeriveChildPublic(xpub, 0, chainCode)
+			if err != nil {
+				t.Fatalf("DeriveChildPublic: %v", err)
+			}
+			if pubChild.Public.X.Cmp(child.Public.X) != 0 || pubChild.Public.Y.Cmp(child.Public.Y) != 0 {
+				t.Fatal("DeriveChild and DeriveChildPublic disagree on the derived public key")
+			}
+			if !bytes.Equal(pubChainCode, childChainCode) {
+				t.Fatal("DeriveChild and DeriveChildPublic disagree on the child chain code")
+			}
+		})
+	}
+}
+
+func TestDeriveChildPublicRejectsHardenedIndex(t *testing.T) {
+	master, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	xpub := &sign.SignKeys{Public: master.Public}
+	if _, _, err := DeriveChildPublic(xpub, HardenedOffset, make([]byte, 32)); err == nil {
+		t.Fatal("DeriveChildPublic should reject a hardened index")
+	}
+}
+
+func TestDeriveChildHardenedDiffersFromNonHardened(t *testing.T) {
+	master, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	chainCode := make([]byte, 32)
+
+	nonHardened, _, err := DeriveChild(master, 0, chainCode)
+	if err != nil {
+		t.Fatalf("DeriveChild: %v", err)
+	}
+	hardened, _, err := DeriveChild(master, HardenedOffset, chainCode)
+	if err != nil {
+		t.Fatalf("DeriveChild: %v", err)
+	}
+	if nonHardened.Private.D.Cmp(hardened.Private.D) == 0 {
+		t.Fatal("hardened and non-hardened derivation at the same base index produced the same child")
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	indices, err := ParsePath("m/44'/0'/0/5")
+	if err != nil {
+		t.Fatalf("ParsePath: %v", err)
+	}
+	want := []uint32{44 + HardenedOffset, 0 + HardenedOffset, 0, 5}
+	if len(indices) != len(want) {
+		t.Fatalf("len(indices) = %d, want %d", len(indices), len(want))
+	}
+	for i := range want {
+		if indices[i] != want[i] {
+			t.Fatalf("indices[%d] = %d, want %d", i, indices[i], want[i])
+		}
+	}
+}
+
+func TestParsePathRejectsMissingRoot(t *testing.T) {
+	if _, err := ParsePath("44'/0'/0/5"); err == nil {
+		t.Fatal("ParsePath should reject a path not starting with \"m\"")
+	}
+}
+
+func TestDerivePathAndNeuter(t *testing.T) {
+	master, err := SetCurve("secp256k1")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+
+	xprv, err := DerivePath(master, make([]byte, 32), "m/44'/0'/0/5")
+	if err != nil {
+		t.Fatalf("DerivePath: %v", err)
+	}
+	if xprv.Depth != 4 {
+		t.Fatalf("Depth = %d, want 4", xprv.Depth)
+	}
+	if xprv.ChildIndex != 5 {
+		t.Fatalf("ChildIndex = %d, want 5", xprv.ChildIndex)
+	}
+	if !xprv.Private {
+		t.Fatal("DerivePath from a private master should produce a private extended key")
+	}
+
+	xpub := xprv.Neuter()
+	if xpub.Private {
+		t.Fatal("Neuter should mark the result as public-only")
+	}
+	if xpub.Keys.Private != nil {
+		t.Fatal("Neuter should leave Keys.Private nil")
+	}
+	if xpub.Keys.Public.X.Cmp(xprv.Keys.Public.X) != 0 {
+		t.Fatal("Neuter changed the public key")
+	}
+}
+
+func TestExtendedKeySerializeVersionByte(t *testing.T) {
+	master, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	xprv, err := DerivePath(master, make([]byte, 32), "m/0")
+	if err != nil {
+		t.Fatalf("DerivePath: %v", err)
+	}
+
+	prvBytes := xprv.Serialize()
+	if !bytes.Equal(prvBytes[:4], versionPrivate[:]) {
+		t.Fatalf("private extended key version = %x, want %x", prvBytes[:4], versionPrivate)
+	}
+
+	pubBytes := xprv.Neuter().Serialize()
+	if !bytes.Equal(pubBytes[:4], versionPublic[:]) {
+		t.Fatalf("public extended key version = %x, want %x", pubBytes[:4], versionPublic)
+	}
+}
@@ -0,0 +1,94 @@
+package eckey
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+func TestECDHSharedSecretAgreement(t *testing.T) {
+	var alice, bob sign.SignKeys
+	if err := alice.Generate(); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if err := bob.Generate(); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	bobPubHex, _ := bob.HexString()
+	alicePubHex, _ := alice.HexString()
+
+	secretFromAlice, err := ECDH(&alice, bobPubHex)
+	if err != nil {
+		t.Fatalf("ECDH (alice side): %v", err)
+	}
+	secretFromBob, err := ECDH(&bob, alicePubHex)
+	if err != nil {
+		t.Fatalf("ECDH (bob side): %v", err)
+	}
+	if !bytes.Equal(secretFromAlice, secretFromBob) {
+		t.Fatal("both sides of ECDH should agree on the shared secret")
+	}
+}
+
+func TestECDHRejectsInvalidPeerKey(t *testing.T) {
+	var alice sign.SignKeys
+	if err := alice.Generate(); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, err := ECDH(&alice, "not-hex"); err == nil {
+		t.Fatal("ECDH with non-hex peer key should fail")
+	}
+	if _, err := ECDH(&alice, hex.EncodeToString([]byte{0x00})); err == nil {
+		t.Fatal("ECDH with a malformed compressed point should fail")
+	}
+}
+
+func TestECDHRequiresPrivateKey(t *testing.T) {
+	var bob sign.SignKeys
+	if err := bob.Generate(); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	bobPubHex, _ := bob.HexString()
+	if _, err := ECDH(&sign.SignKeys{}, bobPubHex); err == nil {
+		t.Fatal("ECDH without a local private key should fail")
+	}
+}
+
+func TestPerform3DHAgreesBothDirections(t *testing.T) {
+	var aliceLong, aliceEph, bobLong, bobEph sign.SignKeys
+	for _, k := range []*sign.SignKeys{&aliceLong, &aliceEph, &bobLong, &bobEph} {
+		if err := k.Generate(); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+
+	initiatorKey, err := Perform3DH(&aliceLong, &aliceEph, bobLong.Public, bobEph.Public, true)
+	if err != nil {
+		t.Fatalf("Perform3DH (initiator): %v", err)
+	}
+	responderKey, err := Perform3DH(&bobLong, &bobEph, aliceLong.Public, aliceEph.Public, false)
+	if err != nil {
+		t.Fatalf("Perform3DH (responder): %v", err)
+	}
+	if !bytes.Equal(initiatorKey, responderKey) {
+		t.Fatal("initiator and responder should derive the same 3DH session key")
+	}
+}
+
+func TestPerform3DHRequiresAllFourKeys(t *testing.T) {
+	var local, localEph, remoteLong, remoteEph sign.SignKeys
+	for _, k := range []*sign.SignKeys{&local, &localEph, &remoteLong, &remoteEph} {
+		if err := k.Generate(); err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+	}
+
+	if _, err := Perform3DH(&sign.SignKeys{}, &localEph, remoteLong.Public, remoteEph.Public, true); err == nil {
+		t.Fatal("Perform3DH without a local long-term private key should fail")
+	}
+	if _, err := Perform3DH(&local, &localEph, nil, remoteEph.Public, true); err == nil {
+		t.Fatal("Perform3DH without a remote long-term public key should fail")
+	}
+}
@@ -0,0 +1,156 @@
+package eckey
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+/*
+ECIES (Elliptic Curve Integrated Encryption Scheme)
+
+This complements the sign/verify-only surface exercised in main(): combined
+with the derivation in derive.go, a Verifier can send a confidential
+per-round payload to a derived Signer identity (e.g. a path like
+"m/44'/0'/0/5") that the Observer, even knowing the derivation index,
+cannot decrypt without the Signer's private key.
+
+  r          = ephemeral keypair
+  S          = r * PubKey               (shared point)
+  key, mac   = HKDF-SHA256(S.X) split into an AES-128-GCM key and a MAC key
+  ciphertext = ephemeralPub || nonce || AES-GCM(msg) || HMAC-SHA256(mac, ephemeralPub||nonce||ct)
+
+The layout mirrors the construction used by dcrec/secp256k1's Encrypt/Decrypt
+and go-ethereum's ECIES: an ephemeral public key prefix so the recipient can
+reconstruct S without any prior exchange, followed by an authenticated
+ciphertext.
+*/
+
+const (
+	eciesKeyLen   = 16 // AES-128
+	eciesMACLen   = 32 // HMAC-SHA256
+	ecieshkdfInfo = "go-eckey-derivation/ecies"
+)
+
+func eciesDeriveKeys(sharedX []byte) (aesKey, macKey []byte, err error) {
+	kdf := hkdf.New(sha256.New, sharedX, nil, []byte(ecieshkdfInfo))
+	out := make([]byte, eciesKeyLen+eciesMACLen)
+	if _, err := io.ReadFull(kdf, out); err != nil {
+		return nil, nil, err
+	}
+	return out[:eciesKeyLen], out[eciesKeyLen:], nil
+}
+
+// Encrypt performs ECIES encryption of msg for recipientPub. The result is
+// self-contained: the recipient only needs their own private key to
+// decrypt it.
+//
+// recipientPub is a typed *ecdsa.PublicKey (e.g. keys.Public from a
+// sign.SignKeys) rather than a raw hex-encoded point: a compressed point's
+// bytes alone don't name their curve (a given byte string can decompress
+// to a "valid" point on more than one curve), so the curve must come from
+// the key itself, not be guessed from its encoding.
+func Encrypt(msg []byte, recipientPub *ecdsa.PublicKey) ([]byte, error) {
+	if recipientPub == nil || recipientPub.Curve == nil {
+		return nil, errors.New("ecies: recipient public key required")
+	}
+	backend := curveBackend(recipientPub.Curve)
+
+	ephemeral, err := ecdsa.GenerateKey(backend, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sx, _ := backend.ScalarMult(recipientPub.X, recipientPub.Y, ephemeral.D.Bytes())
+
+	aesKey, macKey, err := eciesDeriveKeys(sx.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	ciphertext := gcm.Seal(nil, nonce, msg, nil)
+
+	ephemeralPub := backend.Marshal(ephemeral.X, ephemeral.Y)
+
+	out := append([]byte{}, ephemeralPub...)
+	out = append(out, nonce...)
+	out = append(out, ciphertext...)
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(out)
+	out = mac.Sum(out)
+
+	return out, nil
+}
+
+// Decrypt reverses Encrypt using keys.Private. It is a package-level
+// function (rather than a SignKeys method) because SignKeys is defined in
+// gitlab.com/vocdoni/go-dvote, the same convention sign.Verify already
+// uses for the public-key side of an operation.
+func Decrypt(keys *sign.SignKeys, ciphertext []byte) ([]byte, error) {
+	if keys == nil || keys.Private == nil {
+		return nil, errors.New("ecies: private key required")
+	}
+	backend := curveBackend(keys.Private.Curve)
+
+	pointLen := 33 // compressed point on a 256-bit curve
+	if len(ciphertext) < pointLen+12+eciesMACLen {
+		return nil, errors.New("ecies: ciphertext too short")
+	}
+
+	macOffset := len(ciphertext) - eciesMACLen
+	body, gotMAC := ciphertext[:macOffset], ciphertext[macOffset:]
+
+	ephemeralPub := body[:pointLen]
+	x, y, err := backend.Unmarshal(ephemeralPub)
+	if err != nil {
+		return nil, fmt.Errorf("ecies: invalid ephemeral public key: %w", err)
+	}
+
+	sx, _ := backend.ScalarMult(x, y, keys.Private.D.Bytes())
+	aesKey, macKey, err := eciesDeriveKeys(sx.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), gotMAC) {
+		return nil, errors.New("ecies: MAC mismatch")
+	}
+
+	block, err := aes.NewCipher(aesKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := gcm.NonceSize()
+	nonce := body[pointLen : pointLen+nonceSize]
+	ct := body[pointLen+nonceSize:]
+
+	return gcm.Open(nil, nonce, ct, nil)
+}
@@ -0,0 +1,205 @@
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+/*
+Schnorr signatures and MuSig-style key aggregation
+
+A single Schnorr signature (R, s) from an aggregated committee key is a
+more useful primitive than N separate ECDSA signatures when the Verifyer
+wants to challenge a whole committee of derived keys (built with the
+additive derivation in derive.go) at once.
+
+Aggregation: X = sum(a_i * X_i), a_i = H(L, X_i), L = H(X_1 || ... || X_n)
+The per-key coefficients a_i bind each public key to the specific set it
+was aggregated with, preventing a rogue-key attack where a participant
+picks their key as a function of the others' to cancel them out.
+
+Two-round cooperative signing (MuSig-lite):
+  round 1: each signer commits to a nonce (CommitNonce), then reveals it (Reveal)
+  round 2: each signer emits a partial signature over the combined nonce
+           commitment and the aggregated key (PartialSign)
+  combine: CombineSignatures sums the partial signatures into (R, s)
+*/
+
+// SchnorrSignature is a Schnorr signature (R, s) over the curve: R is the
+// nonce's public point and s the scalar response. R is kept as a full point
+// rather than only its X coordinate, so verification needs no point
+// decompression. This package defines no byte encoding for it; in
+// particular it has no 64-byte (R.X || s) form like BIP340's.
+type SchnorrSignature struct {
+	R *ecdsa.PublicKey
+	S *big.Int
+}
+
+func schnorrChallenge(curve elliptic.Curve, rx, px *big.Int, msg []byte) *big.Int {
+	h := sha256.New()
+	h.Write(rx.Bytes())
+	h.Write(px.Bytes())
+	h.Write(msg)
+	return new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), curve.Params().N)
+}
+
+// SchnorrSign produces a Schnorr signature over msg using keys.Private.
+func SchnorrSign(keys *sign.SignKeys, msg []byte) (*SchnorrSignature, error) {
+	if keys == nil || keys.Private == nil {
+		return nil, errors.New("schnorr: private key required")
+	}
+	curve := keys.Private.Curve
+	n := curve.Params().N
+
+	k, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	e := schnorrChallenge(curve, k.X, keys.Public.X, msg)
+	s := new(big.Int).Mul(e, keys.Private.D)
+	s.Add(s, k.D)
+	s.Mod(s, n)
+
+	return &SchnorrSignature{R: &k.PublicKey, S: s}, nil
+}
+
+// SchnorrVerify checks sig against msg and pub: it recomputes
+// e = H(R.X || P.X || msg) and checks s*G == R + e*P.
+func SchnorrVerify(pub *ecdsa.PublicKey, msg []byte, sig *SchnorrSignature) (bool, error) {
+	if pub == nil || sig == nil {
+		return false, errors.New("schnorr: public key and signature required")
+	}
+	curve := pub.Curve
+
+	e := schnorrChallenge(curve, sig.R.X, pub.X, msg)
+
+	sx, sy := curve.ScalarBaseMult(sig.S.Bytes())
+
+	ex, ey := curve.ScalarMult(pub.X, pub.Y, e.Bytes())
+	wantX, wantY := curve.Add(sig.R.X, sig.R.Y, ex, ey)
+
+	return sx.Cmp(wantX) == 0 && sy.Cmp(wantY) == 0, nil
+}
+
+// AggregatePublicKeys computes the MuSig-style aggregated public key
+// X = sum(a_i * X_i) where a_i = H(L, X_i) and L = H(X_1 || ... || X_n),
+// returning X together with the per-key coefficients a_i (needed by each
+// signer during PartialSign).
+func AggregatePublicKeys(pubs []*ecdsa.PublicKey) (*ecdsa.PublicKey, []*big.Int, error) {
+	if len(pubs) == 0 {
+		return nil, nil, errors.New("musig: at least one public key required")
+	}
+	curve := pubs[0].Curve
+	n := curve.Params().N
+	backend := curveBackend(curve)
+
+	lHash := sha256.New()
+	for _, p := range pubs {
+		lHash.Write(backend.Marshal(p.X, p.Y))
+	}
+	l := lHash.Sum(nil)
+
+	coeffs := make([]*big.Int, len(pubs))
+	var aggX, aggY *big.Int
+	for i, p := range pubs {
+		h := sha256.New()
+		h.Write(l)
+		h.Write(backend.Marshal(p.X, p.Y))
+		a := new(big.Int).Mod(new(big.Int).SetBytes(h.Sum(nil)), n)
+		coeffs[i] = a
+
+		px, py := curve.ScalarMult(p.X, p.Y, a.Bytes())
+		if aggX == nil {
+			aggX, aggY = px, py
+		} else {
+			aggX, aggY = curve.Add(aggX, aggY, px, py)
+		}
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: aggX, Y: aggY}, coeffs, nil
+}
+
+// NonceCommitment is round 1 of the cooperative signing protocol: a
+// signer commits to a nonce before revealing it, preventing the last
+// signer to reveal from adaptively choosing their nonce.
+type NonceCommitment struct {
+	Commitment []byte
+	nonce      *ecdsa.PrivateKey
+}
+
+// CommitNonce generates a fresh per-signing-session nonce and returns a
+// hiding commitment to it (SHA-256 of R's compressed encoding); call Reveal on the result to
+// obtain the actual nonce public point once all participants have
+// committed.
+func CommitNonce(curve elliptic.Curve) (*NonceCommitment, error) {
+	k, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(curveBackend(curve).Marshal(k.X, k.Y))
+	return &NonceCommitment{Commitment: h[:], nonce: k}, nil
+}
+
+// Reveal returns the nonce's public point once every participant has
+// published their NonceCommitment.
+func Reveal(nc *NonceCommitment) *ecdsa.PublicKey {
+	return &nc.nonce.PublicKey
+}
+
+// CombineNonces sums every participant's revealed nonce point into the
+// aggregated nonce R used as the Schnorr challenge's commitment.
+func CombineNonces(nonces []*ecdsa.PublicKey) (*ecdsa.PublicKey, error) {
+	if len(nonces) == 0 {
+		return nil, errors.New("musig: at least one nonce required")
+	}
+	curve := nonces[0].Curve
+	x, y := nonces[0].X, nonces[0].Y
+	for _, nonce := range nonces[1:] {
+		x, y = curve.Add(x, y, nonce.X, nonce.Y)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+// PartialSign computes signer keys's share of a MuSig signature: given
+// the aggregated nonce point R (sum of every participant's revealed
+// nonce), the aggregated key X, this signer's coefficient a_i from
+// AggregatePublicKeys, and this signer's own revealed nonce commitment
+// nc, it returns s_i = k_i + e*a_i*priv_i (mod N) where e is the
+// Schnorr-style challenge over (R, X, msg).
+func PartialSign(keys *sign.SignKeys, nc *NonceCommitment, aggNonce, aggPub *ecdsa.PublicKey, coeff *big.Int, msg []byte) (*big.Int, error) {
+	if keys == nil || keys.Private == nil {
+		return nil, errors.New("musig: private key required")
+	}
+	curve := keys.Private.Curve
+	n := curve.Params().N
+
+	e := schnorrChallenge(curve, aggNonce.X, aggPub.X, msg)
+
+	s := new(big.Int).Mul(e, coeff)
+	s.Mul(s, keys.Private.D)
+	s.Add(s, nc.nonce.D)
+	s.Mod(s, n)
+	return s, nil
+}
+
+// CombineSignatures sums the partial signatures from PartialSign into the
+// final (R, s) Schnorr signature over the aggregated key.
+func CombineSignatures(aggNonce *ecdsa.PublicKey, partials []*big.Int) *SchnorrSignature {
+	curve := aggNonce.Curve
+	n := curve.Params().N
+
+	s := big.NewInt(0)
+	for _, p := range partials {
+		s.Add(s, p)
+	}
+	s.Mod(s, n)
+
+	return &SchnorrSignature{R: aggNonce, S: s}
+}
@@ -0,0 +1,141 @@
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+func TestSchnorrSignVerifyRoundTrip(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			keys, err := SetCurve(name)
+			if err != nil {
+				t.Fatalf("SetCurve: %v", err)
+			}
+			msg := []byte("Election 2019031")
+
+			sig, err := SchnorrSign(keys, msg)
+			if err != nil {
+				t.Fatalf("SchnorrSign: %v", err)
+			}
+			ok, err := SchnorrVerify(keys.Public, msg, sig)
+			if err != nil {
+				t.Fatalf("SchnorrVerify: %v", err)
+			}
+			if !ok {
+				t.Fatal("SchnorrVerify returned false for a signature it just produced")
+			}
+
+			ok, err = SchnorrVerify(keys.Public, []byte("tampered"), sig)
+			if err != nil {
+				t.Fatalf("SchnorrVerify: %v", err)
+			}
+			if ok {
+				t.Fatal("SchnorrVerify returned true for a tampered message")
+			}
+		})
+	}
+}
+
+func TestSchnorrSignRequiresPrivateKey(t *testing.T) {
+	if _, err := SchnorrSign(&sign.SignKeys{}, []byte("msg")); err == nil {
+		t.Fatal("SchnorrSign without a private key should fail")
+	}
+}
+
+func TestAggregatePublicKeysRejectsEmpty(t *testing.T) {
+	if _, _, err := AggregatePublicKeys(nil); err == nil {
+		t.Fatal("AggregatePublicKeys with no keys should fail")
+	}
+}
+
+func TestAggregatePublicKeysDifferentSetsProduceDifferentKeys(t *testing.T) {
+	a, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	b, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+	c, err := SetCurve("P-256")
+	if err != nil {
+		t.Fatalf("SetCurve: %v", err)
+	}
+
+	aggAB, _, err := AggregatePublicKeys([]*ecdsa.PublicKey{a.Public, b.Public})
+	if err != nil {
+		t.Fatalf("AggregatePublicKeys: %v", err)
+	}
+	aggAC, _, err := AggregatePublicKeys([]*ecdsa.PublicKey{a.Public, c.Public})
+	if err != nil {
+		t.Fatalf("AggregatePublicKeys: %v", err)
+	}
+	if aggAB.X.Cmp(aggAC.X) == 0 && aggAB.Y.Cmp(aggAC.Y) == 0 {
+		t.Fatal("aggregating different key sets should not produce the same aggregated key")
+	}
+}
+
+// TestMuSigTwoRoundSigning exercises the full cooperative-signing protocol
+// end to end: two signers aggregate their keys, commit to and reveal
+// nonces, each produce a partial signature, and the combined signature
+// must verify against the aggregated key.
+func TestMuSigTwoRoundSigning(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			signer1, err := SetCurve(name)
+			if err != nil {
+				t.Fatalf("SetCurve: %v", err)
+			}
+			signer2, err := SetCurve(name)
+			if err != nil {
+				t.Fatalf("SetCurve: %v", err)
+			}
+
+			aggPub, coeffs, err := AggregatePublicKeys([]*ecdsa.PublicKey{signer1.Public, signer2.Public})
+			if err != nil {
+				t.Fatalf("AggregatePublicKeys: %v", err)
+			}
+
+			backend, err := SelectCurve(name)
+			if err != nil {
+				t.Fatalf("SelectCurve: %v", err)
+			}
+			nc1, err := CommitNonce(backend)
+			if err != nil {
+				t.Fatalf("CommitNonce: %v", err)
+			}
+			nc2, err := CommitNonce(backend)
+			if err != nil {
+				t.Fatalf("CommitNonce: %v", err)
+			}
+
+			aggNonce, err := CombineNonces([]*ecdsa.PublicKey{Reveal(nc1), Reveal(nc2)})
+			if err != nil {
+				t.Fatalf("CombineNonces: %v", err)
+			}
+
+			msg := []byte("Election 2019031 committee challenge")
+			s1, err := PartialSign(signer1, nc1, aggNonce, aggPub, coeffs[0], msg)
+			if err != nil {
+				t.Fatalf("PartialSign (signer1): %v", err)
+			}
+			s2, err := PartialSign(signer2, nc2, aggNonce, aggPub, coeffs[1], msg)
+			if err != nil {
+				t.Fatalf("PartialSign (signer2): %v", err)
+			}
+
+			sig := CombineSignatures(aggNonce, []*big.Int{s1, s2})
+			ok, err := SchnorrVerify(aggPub, msg, sig)
+			if err != nil {
+				t.Fatalf("SchnorrVerify: %v", err)
+			}
+			if !ok {
+				t.Fatal("combined MuSig signature failed to verify against the aggregated key")
+			}
+		})
+	}
+}
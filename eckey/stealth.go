@@ -0,0 +1,125 @@
+package eckey
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	sign "gitlab.com/vocdoni/go-dvote/crypto/signature"
+)
+
+/*
+Stealth addresses
+
+main() demonstrates a single shared N that derives one new pub/priv pair;
+this generalizes it into an unlinkable per-message address. Instead of a
+single Verifyer-published N, the Signer publishes two long-term keys
+(ScanKey, SpendKey). For each challenge, the sender (the role main() calls
+Verifyer) picks a fresh ephemeral r and computes:
+
+  c = H(r * ScanPub)
+  R = r * G                     (published alongside the challenge)
+  P = SpendPub + c * G          (the one-time stealth address)
+
+Only the Signer, holding both scanPriv and spendPriv, can recompute c from
+R and recover p = spendPriv + c (mod N), the private key for P. An
+Observer who sees R and P across many challenges cannot tell they all
+belong to the same Signer, unlike the fixed-N scheme in main().
+*/
+
+// StealthKeys holds the two long-term key pairs a recipient publishes to
+// receive stealth payments/challenges: ScanKey lets them find payments
+// addressed to them, SpendKey lets them spend/respond to those they find.
+type StealthKeys struct {
+	ScanKey  *sign.SignKeys
+	SpendKey *sign.SignKeys
+}
+
+// GenerateStealthKeys creates a fresh ScanKey/SpendKey pair on curve.
+// sign.SignKeys.Generate always produces a secp256k1 key, so the key pairs
+// are built directly on curve rather than through Generate.
+func GenerateStealthKeys(curve elliptic.Curve) (*StealthKeys, error) {
+	scan, err := generateKeysOnCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	spend, err := generateKeysOnCurve(curve)
+	if err != nil {
+		return nil, err
+	}
+	return &StealthKeys{ScanKey: scan, SpendKey: spend}, nil
+}
+
+func generateKeysOnCurve(curve elliptic.Curve) (*sign.SignKeys, error) {
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keys := new(sign.SignKeys)
+	keys.Private = priv
+	keys.Public = &priv.PublicKey
+	return keys, nil
+}
+
+func stealthScalar(curve elliptic.Curve, sharedX, sharedY *big.Int) *big.Int {
+	shared := curveBackend(curve).Marshal(sharedX, sharedY)
+	h := sha256.Sum256(shared)
+	return new(big.Int).Mod(new(big.Int).SetBytes(h[:]), curve.Params().N)
+}
+
+// CreateStealthAddress computes a one-time stealth public key P for the
+// recipient identified by (scanPub, spendPub): it picks a fresh ephemeral
+// keypair r, returns P = spendPub + H(r*scanPub)*G and the ephemeral
+// public key R = r*G that must be published alongside P so the recipient
+// can recompute the same shared secret.
+func CreateStealthAddress(scanPub, spendPub *ecdsa.PublicKey) (stealthPub, ephemeralPub *ecdsa.PublicKey, err error) {
+	if scanPub == nil || spendPub == nil {
+		return nil, nil, errors.New("stealth: scan and spend public keys required")
+	}
+	curve := scanPub.Curve
+
+	ephemeral, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sx, sy := curve.ScalarMult(scanPub.X, scanPub.Y, ephemeral.D.Bytes())
+	c := stealthScalar(curve, sx, sy)
+
+	cx, cy := curve.ScalarBaseMult(c.Bytes())
+	px, py := curve.Add(spendPub.X, spendPub.Y, cx, cy)
+
+	return &ecdsa.PublicKey{Curve: curve, X: px, Y: py}, &ephemeral.PublicKey, nil
+}
+
+// RecoverStealthPrivate lets the recipient, holding stealthKeys, recover
+// the private key for a stealth address published alongside ephemeralPub:
+// it recomputes c = H(scanPriv*R) and returns spendPriv + c (mod N), the
+// private counterpart of the P computed by CreateStealthAddress.
+func RecoverStealthPrivate(stealthKeys *StealthKeys, ephemeralPub *ecdsa.PublicKey) (*ecdsa.PrivateKey, error) {
+	if stealthKeys == nil || stealthKeys.ScanKey == nil || stealthKeys.SpendKey == nil {
+		return nil, errors.New("stealth: scan and spend keys required")
+	}
+	if stealthKeys.ScanKey.Private == nil || stealthKeys.SpendKey.Private == nil {
+		return nil, errors.New("stealth: scan and spend private keys required")
+	}
+	curve := stealthKeys.ScanKey.Private.Curve
+
+	sx, sy := curve.ScalarMult(ephemeralPub.X, ephemeralPub.Y, stealthKeys.ScanKey.Private.D.Bytes())
+	c := stealthScalar(curve, sx, sy)
+
+	d := new(big.Int).Add(stealthKeys.SpendKey.Private.D, c)
+	d.Mod(d, curve.Params().N)
+	if d.Sign() == 0 {
+		return nil, errors.New("stealth: derived private key is zero, discard this address")
+	}
+
+	priv := new(ecdsa.PrivateKey)
+	priv.Curve = curve
+	priv.D = d
+	priv.X, priv.Y = curve.ScalarBaseMult(d.Bytes())
+	return priv, nil
+}
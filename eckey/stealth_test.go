@@ -0,0 +1,90 @@
+package eckey
+
+import (
+	"crypto/elliptic"
+	"testing"
+)
+
+func TestStealthAddressRoundTrip(t *testing.T) {
+	for _, name := range []string{"P-256", "secp256k1"} {
+		t.Run(name, func(t *testing.T) {
+			backend, err := SelectCurve(name)
+			if err != nil {
+				t.Fatalf("SelectCurve(%q): %v", name, err)
+			}
+			keys, err := GenerateStealthKeys(backend)
+			if err != nil {
+				t.Fatalf("GenerateStealthKeys: %v", err)
+			}
+
+			stealthPub, ephemeralPub, err := CreateStealthAddress(keys.ScanKey.Public, keys.SpendKey.Public)
+			if err != nil {
+				t.Fatalf("CreateStealthAddress: %v", err)
+			}
+
+			recoveredPriv, err := RecoverStealthPrivate(keys, ephemeralPub)
+			if err != nil {
+				t.Fatalf("RecoverStealthPrivate: %v", err)
+			}
+			if recoveredPriv.X.Cmp(stealthPub.X) != 0 || recoveredPriv.Y.Cmp(stealthPub.Y) != 0 {
+				t.Fatal("recovered private key's public point doesn't match the published stealth address")
+			}
+
+			backendOps := curveBackend(backend)
+			wantX, wantY := backendOps.ScalarBaseMult(recoveredPriv.D.Bytes())
+			if wantX.Cmp(stealthPub.X) != 0 || wantY.Cmp(stealthPub.Y) != 0 {
+				t.Fatal("recovered private scalar doesn't actually produce the stealth public point")
+			}
+		})
+	}
+}
+
+func TestGenerateStealthKeysHonorsCurve(t *testing.T) {
+	keys, err := GenerateStealthKeys(elliptic.P384())
+	if err != nil {
+		t.Fatalf("GenerateStealthKeys: %v", err)
+	}
+	if keys.ScanKey.Public.Curve.Params().Name != elliptic.P384().Params().Name {
+		t.Fatalf("ScanKey curve = %q, want %q", keys.ScanKey.Public.Curve.Params().Name, elliptic.P384().Params().Name)
+	}
+	if keys.SpendKey.Public.Curve.Params().Name != elliptic.P384().Params().Name {
+		t.Fatalf("SpendKey curve = %q, want %q", keys.SpendKey.Public.Curve.Params().Name, elliptic.P384().Params().Name)
+	}
+}
+
+func TestCreateStealthAddressRejectsNilKeys(t *testing.T) {
+	keys, err := GenerateStealthKeys(elliptic.P256())
+	if err != nil {
+		t.Fatalf("GenerateStealthKeys: %v", err)
+	}
+	if _, _, err := CreateStealthAddress(nil, keys.SpendKey.Public); err == nil {
+		t.Fatal("CreateStealthAddress with a nil scan key should fail")
+	}
+	if _, _, err := CreateStealthAddress(keys.ScanKey.Public, nil); err == nil {
+		t.Fatal("CreateStealthAddress with a nil spend key should fail")
+	}
+}
+
+func TestRecoverStealthPrivateWithOtherRecipientKeysMissesAddress(t *testing.T) {
+	keys, err := GenerateStealthKeys(elliptic.P256())
+	if err != nil {
+		t.Fatalf("GenerateStealthKeys: %v", err)
+	}
+	other, err := GenerateStealthKeys(elliptic.P256())
+	if err != nil {
+		t.Fatalf("GenerateStealthKeys: %v", err)
+	}
+
+	stealthPub, ephemeralPub, err := CreateStealthAddress(keys.ScanKey.Public, keys.SpendKey.Public)
+	if err != nil {
+		t.Fatalf("CreateStealthAddress: %v", err)
+	}
+
+	recoveredByOther, err := RecoverStealthPrivate(other, ephemeralPub)
+	if err != nil {
+		t.Fatalf("RecoverStealthPrivate: %v", err)
+	}
+	if recoveredByOther.X.Cmp(stealthPub.X) == 0 && recoveredByOther.Y.Cmp(stealthPub.Y) == 0 {
+		t.Fatal("a different recipient's stealth keys should not recover the same private key")
+	}
+}